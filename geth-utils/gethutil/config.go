@@ -0,0 +1,77 @@
+package gethutil
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/logger"
+)
+
+// Account is the pre-state of a single account, as supplied by the caller to
+// seed the in-memory StateDB before a trace is run. Nonce, Balance and Code
+// are pointers/nilable so that an account overlaid on top of a
+// LedgerHandle's hydrated state (see newStateDB) can touch just one field
+// without the others being reset to zero.
+type Account struct {
+	Address common.Address              `json:"address"`
+	Nonce   *hexutil.Uint64             `json:"nonce"`
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// Transaction is the input representation of a transaction to be traced. It
+// carries the superset of fields needed by legacy, EIP-2930 access-list and
+// EIP-1559 dynamic-fee transactions; which fields are populated determines
+// the tx type that gets built in Trace.
+type Transaction struct {
+	From       common.Address   `json:"from"`
+	To         *common.Address  `json:"to"`
+	Nonce      hexutil.Uint64   `json:"nonce"`
+	GasLimit   hexutil.Uint64   `json:"gas_limit"`
+	Value      *hexutil.Big     `json:"value"`
+	GasPrice   *hexutil.Big     `json:"gas_price"`
+	GasFeeCap  *hexutil.Big     `json:"gas_fee_cap"`
+	GasTipCap  *hexutil.Big     `json:"gas_tip_cap"`
+	CallData   hexutil.Bytes    `json:"call_data"`
+	AccessList types.AccessList `json:"access_list"`
+	V          hexutil.Uint64   `json:"v"`
+	R          *hexutil.Big     `json:"r"`
+	S          *hexutil.Big     `json:"s"`
+}
+
+// BlockConstants are the block-level values the EVM needs but that aren't
+// part of any account or transaction.
+type BlockConstants struct {
+	Coinbase   common.Address `json:"coinbase"`
+	Timestamp  *hexutil.Big   `json:"timestamp"`
+	Number     *hexutil.Big   `json:"number"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+	GasLimit   *hexutil.Big   `json:"gas_limit"`
+	BaseFee    *hexutil.Big   `json:"base_fee"`
+}
+
+// TraceConfig is the JSON payload CreateTrace unmarshals from the Rust side.
+type TraceConfig struct {
+	ChainID        hexutil.Big                `json:"chain_id"`
+	HistoryHashes  []*hexutil.Big             `json:"history_hashes"`
+	BlockConstants BlockConstants             `json:"block_constants"`
+	Accounts       map[common.Address]Account `json:"accounts"`
+	Transactions   []Transaction              `json:"transactions"`
+	LoggerConfig   logger.Config              `json:"logger_config"`
+
+	// Tracer optionally names a geth debug_traceTransaction-style tracer
+	// ("callTracer", "prestateTracer", "4byteTracer", "noopTracer", or a JS
+	// snippet) to run instead of the default struct-log tracer. TracerConfig
+	// is passed through to it verbatim.
+	Tracer       string          `json:"tracer,omitempty"`
+	TracerConfig json.RawMessage `json:"tracer_config,omitempty"`
+
+	// LedgerHandle optionally names a ledger previously opened via
+	// OpenLedger to pre-seed the StateDB from, so callers tracing the same
+	// chain state repeatedly don't have to ship the full Accounts map through
+	// JSON every time. Accounts still acts as an overlay on top of it.
+	LedgerHandle uint64 `json:"ledger_handle,omitempty"`
+}
@@ -0,0 +1,62 @@
+package gethutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/logger"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+
+	// Register the native and JS tracer lookups with tracers.DefaultDirectory.
+	_ "github.com/ethereum/go-ethereum/eth/tracers/js"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+// Result is the discriminated union CreateTrace returns: Tracer is "" for
+// the default struct-log format and one of the native/JS tracer names
+// otherwise, and Results holds one pre-encoded entry per transaction in that
+// tracer's own shape. Keeping the shape opaque past this point lets Rust
+// callers decode only what they asked for instead of paying to marshal a
+// full struct log when all they wanted was call frames.
+type Result struct {
+	Tracer  string            `json:"tracer"`
+	Results []json.RawMessage `json:"results"`
+}
+
+// newTracer builds the vm.EVMLogger to drive this trace with: the default
+// struct logger when config.Tracer is unset, otherwise the named native or
+// JS tracer looked up through tracers.DefaultDirectory.
+func newTracer(config TraceConfig) (vm.EVMLogger, error) {
+	if config.Tracer == "" {
+		return logger.NewStructLogger(&config.LoggerConfig), nil
+	}
+
+	t, err := tracers.DefaultDirectory.New(config.Tracer, new(tracers.Context), config.TracerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracer %q: %w", config.Tracer, err)
+	}
+	return t, nil
+}
+
+// tracerResult extracts the per-transaction payload out of t once the
+// transaction has finished executing. Native/JS tracers produce their own
+// JSON via GetResult; the default struct logger falls back to the
+// ExecutionResult shape traced callers already expect.
+func tracerResult(t vm.EVMLogger, execResult *core.ExecutionResult) (json.RawMessage, error) {
+	if tr, ok := t.(tracers.Tracer); ok {
+		return tr.GetResult()
+	}
+
+	sl, ok := t.(*logger.StructLogger)
+	if !ok {
+		return nil, fmt.Errorf("unexpected tracer type %T", t)
+	}
+	return json.Marshal(ExecutionResult{
+		Gas:         execResult.UsedGas,
+		Failed:      execResult.Failed(),
+		ReturnValue: fmt.Sprintf("%x", execResult.ReturnData),
+		StructLogs:  sl.StructLogs(),
+	})
+}
@@ -0,0 +1,45 @@
+package gethutil
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/state"
+
+	"main/gethutil/ledger"
+)
+
+// hydrateFromLedger seeds statedb from the ledger registered under
+// config.LedgerHandle, as of the block number being traced.
+func hydrateFromLedger(statedb *state.StateDB, config TraceConfig) error {
+	l, ok := ledger.Lookup(config.LedgerHandle)
+	if !ok {
+		return fmt.Errorf("unknown ledger handle %d", config.LedgerHandle)
+	}
+
+	var height uint64
+	if config.BlockConstants.Number != nil {
+		height = (*big.Int)(config.BlockConstants.Number).Uint64()
+	}
+
+	accounts, code, storage, err := l.Snapshot(height).Accounts()
+	if err != nil {
+		return fmt.Errorf("failed to read ledger state at height %d: %w", height, err)
+	}
+
+	for addr, acc := range accounts {
+		statedb.SetNonce(addr, acc.Nonce)
+		if acc.Balance != nil {
+			statedb.SetBalance(addr, acc.Balance)
+		}
+	}
+	for addr, c := range code {
+		statedb.SetCode(addr, c)
+	}
+	for addr, slots := range storage {
+		for slot, value := range slots {
+			statedb.SetState(addr, slot, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package ledger provides a persistent, Pebble-backed key/value store that
+// can sit underneath an in-memory state.StateDB, so repeated traces against
+// the same chain state don't have to re-hydrate every account from a
+// TraceConfig.accounts JSON blob on every call. Callers open one Ledger per
+// chain/dataset, seed it once, and then point many TraceConfig values at it
+// via a handle.
+package ledger
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// Ledger is a persistent key/value store backing repeated traces against
+// the same chain state.
+type Ledger struct {
+	db *pebble.DB
+}
+
+// Open opens (creating if necessary) the Pebble store at path.
+func Open(path string) (*Ledger, error) {
+	db, err := pebble.Open(path, &pebble.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger at %q: %w", path, err)
+	}
+	return &Ledger{db: db}, nil
+}
+
+// Close releases the underlying Pebble store.
+func (l *Ledger) Close() error {
+	return l.db.Close()
+}
+
+var (
+	handlesMu  sync.Mutex
+	handles    = map[uint64]*Ledger{}
+	nextHandle uint64
+)
+
+// Register makes l reachable by handle, for TraceConfig.ledger_handle to
+// reference from across the CGO boundary.
+func Register(l *Ledger) uint64 {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+	nextHandle++
+	handles[nextHandle] = l
+	return nextHandle
+}
+
+// Lookup resolves a handle previously returned by Register.
+func Lookup(handle uint64) (*Ledger, bool) {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+	l, ok := handles[handle]
+	return l, ok
+}
+
+// Close releases the ledger registered under handle and forgets it.
+func Close(handle uint64) error {
+	handlesMu.Lock()
+	l, ok := handles[handle]
+	delete(handles, handle)
+	handlesMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown ledger handle %d", handle)
+	}
+	return l.Close()
+}
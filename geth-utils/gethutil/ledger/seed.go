@@ -0,0 +1,45 @@
+package ledger
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SeedAccount is one entry of a bulk seed request: the full picture for a
+// single address, mirroring the shape TraceConfig.accounts already uses so
+// callers can hand the ledger the same mainnet-like state they'd otherwise
+// have to inline into every trace.
+type SeedAccount struct {
+	Address common.Address              `json:"address"`
+	Nonce   hexutil.Uint64              `json:"nonce"`
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// Seed writes accounts (with their code and storage) into the ledger at
+// height. This is the entry point for pre-seeding a large chain state once
+// so that many later traces can run against it without shipping the full
+// accounts map through JSON each time.
+func (l *Ledger) Seed(height uint64, accounts []SeedAccount) error {
+	s := l.Snapshot(height)
+	for _, a := range accounts {
+		if err := s.PutAccount(a.Address, Account{Nonce: uint64(a.Nonce), Balance: (*big.Int)(a.Balance)}); err != nil {
+			return fmt.Errorf("failed to seed account %s: %w", a.Address, err)
+		}
+		if len(a.Code) > 0 {
+			if err := s.PutCode(a.Address, a.Code); err != nil {
+				return fmt.Errorf("failed to seed code for %s: %w", a.Address, err)
+			}
+		}
+		for slot, value := range a.Storage {
+			if err := s.PutStorage(a.Address, slot, value); err != nil {
+				return fmt.Errorf("failed to seed storage for %s: %w", a.Address, err)
+			}
+		}
+	}
+	return nil
+}
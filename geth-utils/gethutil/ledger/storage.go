@@ -0,0 +1,247 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Key prefixes, one byte each, namespacing the three kinds of data a ledger
+// stores under a single Pebble instance.
+const (
+	prefixAccount byte = 0x01
+	prefixStorage byte = 0x02
+	prefixCode    byte = 0x03
+)
+
+const heightLen = 8
+
+// Account is the persisted state of a single account, everything a
+// state.StateDB needs except its code and storage slots, which live under
+// their own key prefixes.
+type Account struct {
+	Nonce   uint64
+	Balance *big.Int
+}
+
+func encodeHeight(height uint64) []byte {
+	b := make([]byte, heightLen)
+	binary.BigEndian.PutUint64(b, height)
+	return b
+}
+
+func accountPrefix(addr common.Address) []byte {
+	key := make([]byte, 0, 1+common.AddressLength)
+	key = append(key, prefixAccount)
+	return append(key, addr[:]...)
+}
+
+func storagePrefix(addr common.Address, slot common.Hash) []byte {
+	key := make([]byte, 0, 1+common.AddressLength+common.HashLength)
+	key = append(key, prefixStorage)
+	key = append(key, addr[:]...)
+	return append(key, slot[:]...)
+}
+
+func codePrefix(addr common.Address) []byte {
+	key := make([]byte, 0, 1+common.AddressLength)
+	key = append(key, prefixCode)
+	return append(key, addr[:]...)
+}
+
+// Storage is a height-scoped view over a Ledger: writes made through it are
+// recorded at Height, and reads return the most recent write at or before
+// Height, so tracing block N never sees a write made while tracing block
+// N+1.
+type Storage struct {
+	ledger *Ledger
+	Height uint64
+}
+
+// Snapshot returns a Storage view of l as of height.
+func (l *Ledger) Snapshot(height uint64) *Storage {
+	return &Storage{ledger: l, Height: height}
+}
+
+// get returns the value written under fixedPrefix at the highest height <=
+// s.Height, if any.
+func (s *Storage) get(fixedPrefix []byte) ([]byte, bool, error) {
+	it, err := s.ledger.db.NewIter(&pebble.IterOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	defer it.Close()
+
+	upper := append(append([]byte{}, fixedPrefix...), encodeHeight(s.Height+1)...)
+	if !it.SeekLT(upper) {
+		return nil, false, nil
+	}
+	key := it.Key()
+	if len(key) != len(fixedPrefix)+heightLen || !bytes.Equal(key[:len(fixedPrefix)], fixedPrefix) {
+		return nil, false, nil
+	}
+	return append([]byte{}, it.Value()...), true, nil
+}
+
+func (s *Storage) put(fixedPrefix []byte, value []byte) error {
+	key := append(append([]byte{}, fixedPrefix...), encodeHeight(s.Height)...)
+	return s.ledger.db.Set(key, value, pebble.Sync)
+}
+
+// GetAccount returns addr's persisted account record as of s.Height.
+func (s *Storage) GetAccount(addr common.Address) (Account, bool, error) {
+	raw, ok, err := s.get(accountPrefix(addr))
+	if err != nil || !ok {
+		return Account{}, ok, err
+	}
+	var acc Account
+	if err := json.Unmarshal(raw, &acc); err != nil {
+		return Account{}, false, fmt.Errorf("failed to decode account %s: %w", addr, err)
+	}
+	return acc, true, nil
+}
+
+// PutAccount persists addr's account record at s.Height.
+func (s *Storage) PutAccount(addr common.Address, acc Account) error {
+	raw, err := json.Marshal(acc)
+	if err != nil {
+		return fmt.Errorf("failed to encode account %s: %w", addr, err)
+	}
+	return s.put(accountPrefix(addr), raw)
+}
+
+// GetStorage returns the value stored in addr's slot as of s.Height.
+func (s *Storage) GetStorage(addr common.Address, slot common.Hash) (common.Hash, bool, error) {
+	raw, ok, err := s.get(storagePrefix(addr, slot))
+	if err != nil || !ok {
+		return common.Hash{}, ok, err
+	}
+	return common.BytesToHash(raw), true, nil
+}
+
+// PutStorage persists a value for addr's slot at s.Height.
+func (s *Storage) PutStorage(addr common.Address, slot, value common.Hash) error {
+	return s.put(storagePrefix(addr, slot), value[:])
+}
+
+// GetCode returns addr's persisted contract code as of s.Height.
+func (s *Storage) GetCode(addr common.Address) ([]byte, bool, error) {
+	return s.get(codePrefix(addr))
+}
+
+// PutCode persists addr's contract code at s.Height.
+func (s *Storage) PutCode(addr common.Address, code []byte) error {
+	return s.put(codePrefix(addr), code)
+}
+
+// Accounts scans every address the ledger has ever recorded and returns its
+// account record, code, and storage as of s.Height. It is how a fresh
+// state.StateDB gets hydrated from a ledger before config.Accounts is
+// overlaid on top; see gethutil.newStateDB.
+func (s *Storage) Accounts() (map[common.Address]Account, map[common.Address][]byte, map[common.Address]map[common.Hash]common.Hash, error) {
+	accounts := map[common.Address]Account{}
+	code := map[common.Address][]byte{}
+	storage := map[common.Address]map[common.Hash]common.Hash{}
+
+	if err := s.scanLatestPerKey(prefixAccount, func(key, value []byte) error {
+		addr := common.BytesToAddress(key[1 : 1+common.AddressLength])
+		var acc Account
+		if err := json.Unmarshal(value, &acc); err != nil {
+			return fmt.Errorf("failed to decode account %s: %w", addr, err)
+		}
+		accounts[addr] = acc
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := s.scanLatestPerKey(prefixCode, func(key, value []byte) error {
+		addr := common.BytesToAddress(key[1 : 1+common.AddressLength])
+		code[addr] = append([]byte{}, value...)
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	const slotEnd = 1 + common.AddressLength + common.HashLength
+	if err := s.scanLatestPerKey(prefixStorage, func(key, value []byte) error {
+		addr := common.BytesToAddress(key[1 : 1+common.AddressLength])
+		slot := common.BytesToHash(key[1+common.AddressLength : slotEnd])
+		if storage[addr] == nil {
+			storage[addr] = map[common.Hash]common.Hash{}
+		}
+		storage[addr][slot] = common.BytesToHash(value)
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return accounts, code, storage, nil
+}
+
+// scanLatestPerKey walks every key under prefix and calls fn exactly once
+// per fixed key (address, or address+slot) with the value as of the
+// highest height <= s.Height. Versions for the same fixed part are
+// contiguous and height-ascending: once a version's height exceeds
+// s.Height, every later version in that group does too, so rather than
+// visiting them all we seek straight past the group.
+func (s *Storage) scanLatestPerKey(prefix byte, fn func(key, value []byte) error) error {
+	it, err := s.ledger.db.NewIter(&pebble.IterOptions{
+		LowerBound: []byte{prefix},
+		UpperBound: []byte{prefix + 1},
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	var (
+		haveLatest  bool
+		latestFixed []byte
+		latestKey   []byte
+		latestValue []byte
+	)
+	flush := func() error {
+		if !haveLatest {
+			return nil
+		}
+		haveLatest = false
+		return fn(latestKey, latestValue)
+	}
+
+	for it.First(); it.Valid(); {
+		key := it.Key()
+		fixed := key[:len(key)-heightLen]
+		height := binary.BigEndian.Uint64(key[len(key)-heightLen:])
+
+		if !bytes.Equal(fixed, latestFixed) {
+			if err := flush(); err != nil {
+				return err
+			}
+			latestFixed = append([]byte{}, fixed...)
+		}
+
+		if height <= s.Height {
+			latestKey = append([]byte{}, key...)
+			latestValue = append([]byte{}, it.Value()...)
+			haveLatest = true
+			it.Next()
+			continue
+		}
+
+		// Past s.Height for this group: seek to one byte beyond the largest
+		// possible key in it (fixed + max height) instead of scanning the
+		// rest of its versions one at a time.
+		seekTo := append(append([]byte{}, fixed...), bytes.Repeat([]byte{0xff}, heightLen)...)
+		it.SeekGE(append(seekTo, 0x00))
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return it.Error()
+}
@@ -0,0 +1,36 @@
+package gethutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// StreamTrace behaves like Trace but writes each transaction's tracer-shaped
+// result straight to w as it is produced, instead of accumulating the whole
+// slice in memory and calling json.MarshalIndent at the end. For blocks with
+// thousands of steps per tx this keeps peak memory proportional to one
+// transaction's trace rather than the whole block's.
+func StreamTrace(config TraceConfig, w io.Writer) error {
+	if _, err := fmt.Fprintf(w, `{"tracer":%q,"results":[`, config.Tracer); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	first := true
+	err := traceTxs(config, func(i int, raw json.RawMessage) error {
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		return enc.Encode(raw)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]}")
+	return err
+}
@@ -0,0 +1,279 @@
+package gethutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/logger"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ExecutionResult is the per-transaction outcome returned to the Rust caller.
+type ExecutionResult struct {
+	Gas         uint64             `json:"gas"`
+	Failed      bool               `json:"failed"`
+	ReturnValue string             `json:"returnValue"`
+	StructLogs  []logger.StructLog `json:"structLogs"`
+}
+
+// chainConfig returns the params.ChainConfig to trace against. London (and
+// everything before it) is always enabled so that typed transactions and the
+// BASEFEE opcode behave the way a post-London geth node would; this mirrors
+// the fork set the zkevm circuits currently target.
+func chainConfig(chainID *big.Int) *params.ChainConfig {
+	cfg := *params.AllEthashProtocolChanges
+	cfg.ChainID = chainID
+	return &cfg
+}
+
+// toGethTx builds the concrete *types.Transaction for tx, inferring its type
+// from which fields are populated: a non-zero fee cap/tip cap means type-2
+// (EIP-1559), otherwise a non-empty access list means type-1 (EIP-2930) —
+// checked in that order since an EIP-1559 tx commonly carries an access
+// list too — otherwise it's a legacy transaction.
+func toGethTx(tx Transaction) *types.Transaction {
+	var value, gasPrice, gasFeeCap, gasTipCap big.Int
+	if tx.Value != nil {
+		value = big.Int(*tx.Value)
+	}
+	if tx.GasPrice != nil {
+		gasPrice = big.Int(*tx.GasPrice)
+	}
+	if tx.GasFeeCap != nil {
+		gasFeeCap = big.Int(*tx.GasFeeCap)
+	}
+	if tx.GasTipCap != nil {
+		gasTipCap = big.Int(*tx.GasTipCap)
+	}
+
+	var r, s big.Int
+	if tx.R != nil {
+		r = big.Int(*tx.R)
+	}
+	if tx.S != nil {
+		s = big.Int(*tx.S)
+	}
+	v := new(big.Int).SetUint64(uint64(tx.V))
+
+	switch {
+	case gasFeeCap.Sign() != 0 || gasTipCap.Sign() != 0:
+		return types.NewTx(&types.DynamicFeeTx{
+			Nonce:      uint64(tx.Nonce),
+			To:         tx.To,
+			Value:      &value,
+			Gas:        uint64(tx.GasLimit),
+			GasFeeCap:  &gasFeeCap,
+			GasTipCap:  &gasTipCap,
+			Data:       tx.CallData,
+			AccessList: tx.AccessList,
+			V:          v,
+			R:          &r,
+			S:          &s,
+		})
+	case len(tx.AccessList) > 0:
+		return types.NewTx(&types.AccessListTx{
+			Nonce:      uint64(tx.Nonce),
+			To:         tx.To,
+			Value:      &value,
+			Gas:        uint64(tx.GasLimit),
+			GasPrice:   &gasPrice,
+			Data:       tx.CallData,
+			AccessList: tx.AccessList,
+			V:          v,
+			R:          &r,
+			S:          &s,
+		})
+	default:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(tx.Nonce),
+			To:       tx.To,
+			Value:    &value,
+			Gas:      uint64(tx.GasLimit),
+			GasPrice: &gasPrice,
+			Data:     tx.CallData,
+			V:        v,
+			R:        &r,
+			S:        &s,
+		})
+	}
+}
+
+// toMessage builds the core.Message to execute tx as. `from` is always
+// tx.From directly rather than recovered from the signature: the zkevm flow
+// (and the self-test in lib/lib.go's main) traces transactions with
+// an explicit `from` and a zero signature, which signature recovery would
+// reject outright with "invalid signature". The effective gas price is
+// computed the same way AsMessage does it for a London-or-later block:
+// min(tip + baseFee, feeCap).
+func toMessage(tx Transaction, baseFee *big.Int) types.Message {
+	var value, gasPrice, gasFeeCap, gasTipCap big.Int
+	if tx.Value != nil {
+		value = big.Int(*tx.Value)
+	}
+	if tx.GasPrice != nil {
+		gasPrice = big.Int(*tx.GasPrice)
+	}
+	if tx.GasFeeCap != nil {
+		gasFeeCap = big.Int(*tx.GasFeeCap)
+	}
+	if tx.GasTipCap != nil {
+		gasTipCap = big.Int(*tx.GasTipCap)
+	}
+
+	isDynamicFee := gasFeeCap.Sign() != 0 || gasTipCap.Sign() != 0
+	if !isDynamicFee {
+		// Legacy and access-list txs only carry a single gas price; geth's
+		// AsMessage reuses it as both the fee cap and the tip cap so the
+		// London fee-cap check (gasFeeCap >= baseFee) doesn't reject every
+		// legacy tx in a base-fee block.
+		gasFeeCap = gasPrice
+		gasTipCap = gasPrice
+	}
+
+	effectiveGasPrice := new(big.Int).Set(&gasPrice)
+	if isDynamicFee {
+		effectiveGasPrice = new(big.Int).Set(&gasFeeCap)
+		if baseFee != nil {
+			effectiveGasPrice = math.BigMin(new(big.Int).Add(&gasTipCap, baseFee), &gasFeeCap)
+		}
+	}
+
+	return types.NewMessage(
+		tx.From,
+		tx.To,
+		uint64(tx.Nonce),
+		&value,
+		uint64(tx.GasLimit),
+		effectiveGasPrice,
+		&gasFeeCap,
+		&gasTipCap,
+		tx.CallData,
+		tx.AccessList,
+		false,
+	)
+}
+
+// newStateDB builds a fresh in-memory StateDB. When config.LedgerHandle
+// names an open ledger, it is hydrated from that ledger's state as of the
+// block number being traced first; config.Accounts is then applied on top
+// as an overlay, so existing callers that only ever set Accounts see no
+// change in behavior.
+func newStateDB(config TraceConfig) (*state.StateDB, error) {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, err := state.New(common.Hash{}, db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create StateDB: %w", err)
+	}
+
+	if config.LedgerHandle != 0 {
+		if err := hydrateFromLedger(statedb, config); err != nil {
+			return nil, err
+		}
+	}
+
+	for addr, account := range config.Accounts {
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Balance != nil {
+			statedb.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, account.Code)
+		}
+		for k, v := range account.Storage {
+			statedb.SetState(addr, k, v)
+		}
+	}
+	return statedb, nil
+}
+
+// Trace runs every transaction in config against a freshly seeded StateDB
+// and returns one tracer-shaped result per transaction, in order. Which
+// shape that is depends on config.Tracer: the default struct-log format when
+// unset, or whatever the named native/JS tracer produces.
+func Trace(config TraceConfig) (*Result, error) {
+	results := make([]json.RawMessage, len(config.Transactions))
+	if err := traceTxs(config, func(i int, raw json.RawMessage) error {
+		results[i] = raw
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &Result{Tracer: config.Tracer, Results: results}, nil
+}
+
+// traceTxs runs every transaction in config against a freshly seeded
+// StateDB and invokes emit with each transaction's tracer-shaped result, in
+// order, as soon as it is produced. It is the shared core behind Trace,
+// which collects emit's output into a slice, and StreamTrace, which writes
+// it straight through to an io.Writer.
+func traceTxs(config TraceConfig, emit func(i int, raw json.RawMessage) error) error {
+	chainID := (*big.Int)(&config.ChainID)
+	cfg := chainConfig(chainID)
+
+	var baseFee *big.Int
+	if config.BlockConstants.BaseFee != nil {
+		baseFee = (*big.Int)(config.BlockConstants.BaseFee)
+	}
+
+	blockCtx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(n uint64) common.Hash { return common.Hash{} },
+		Coinbase:    config.BlockConstants.Coinbase,
+		BlockNumber: (*big.Int)(config.BlockConstants.Number),
+		Time:        (*big.Int)(config.BlockConstants.Timestamp),
+		Difficulty:  (*big.Int)(config.BlockConstants.Difficulty),
+		GasLimit:    (*big.Int)(config.BlockConstants.GasLimit).Uint64(),
+		BaseFee:     baseFee,
+	}
+
+	statedb, err := newStateDB(config)
+	if err != nil {
+		return err
+	}
+
+	for i, tx := range config.Transactions {
+		gethTx := toGethTx(tx)
+		msg := toMessage(tx, baseFee)
+
+		txCtx := vm.TxContext{
+			Origin:   msg.From(),
+			GasPrice: msg.GasPrice(),
+		}
+
+		tracer, err := newTracer(config)
+		if err != nil {
+			return err
+		}
+		vmCfg := vm.Config{Debug: true, Tracer: tracer, NoBaseFee: baseFee == nil}
+
+		evm := vm.NewEVM(blockCtx, txCtx, statedb, cfg, vmCfg)
+
+		statedb.SetTxContext(gethTx.Hash(), i)
+		gp := new(core.GasPool).AddGas(msg.Gas())
+		execResult, err := core.ApplyMessage(evm, msg, gp)
+		if err != nil {
+			return fmt.Errorf("failed to apply tx %d: %w", i, err)
+		}
+
+		raw, err := tracerResult(tracer, execResult)
+		if err != nil {
+			return fmt.Errorf("failed to extract tracer result for tx %d: %w", i, err)
+		}
+		if err := emit(i, raw); err != nil {
+			return fmt.Errorf("failed to emit result for tx %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+/*
+   #include <stdlib.h>
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"main/gethutil"
+)
+
+// tracesRequest is the payload for CreateTraces: a batch of TraceConfig
+// values to run concurrently, plus an optional worker pool size.
+type tracesRequest struct {
+	Configs []gethutil.TraceConfig `json:"configs"`
+	Workers int                    `json:"workers,omitempty"`
+}
+
+// BatchResult is one slot of CreateTraces' output, in the same order as the
+// input configs. Exactly one of Result or Err is set, so a single bad
+// transaction in a batch doesn't take down the whole round-trip.
+type BatchResult struct {
+	Result *gethutil.Result `json:"result,omitempty"`
+	Err    string           `json:"err,omitempty"`
+}
+
+// tracesResponse is the single shape CreateTraces always returns: exactly
+// one of Err (a top-level failure, before any trace ran) or Results (one
+// BatchResult per input config) is set. Callers never have to sniff
+// array-vs-object to know how to parse the payload.
+type tracesResponse struct {
+	Err     string        `json:"err,omitempty"`
+	Results []BatchResult `json:"results"`
+}
+
+func (r tracesResponse) cString() *C.char {
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		// json.Marshal can't fail on this shape; this is a last resort.
+		return C.CString(fmt.Sprintf(`{"err":%q}`, fmt.Sprintf("failed to marshal response: %v", err)))
+	}
+	return C.CString(string(bytes))
+}
+
+// CreateTraces runs a batch of TraceConfig values concurrently over a
+// bounded worker pool (size Workers, defaulting to GOMAXPROCS) and returns
+// one BatchResult per config, in input order, wrapped in a tracesResponse.
+// Each worker builds its trace from its own config's accounts, so there is
+// no state shared between concurrent traces.
+//
+//export CreateTraces
+func CreateTraces(configsStr *C.char) *C.char {
+	var req tracesRequest
+	if err := json.Unmarshal([]byte(C.GoString(configsStr)), &req); err != nil {
+		return tracesResponse{Err: fmt.Sprintf("failed to unmarshal configs: %v", err)}.cString()
+	}
+
+	if len(req.Configs) == 0 {
+		return tracesResponse{Results: []BatchResult{}}.cString()
+	}
+
+	workers := req.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(req.Configs) {
+		workers = len(req.Configs)
+	}
+
+	results := make([]BatchResult, len(req.Configs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = traceOne(req.Configs[i])
+			}
+		}()
+	}
+	for i := range req.Configs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return tracesResponse{Results: results}.cString()
+}
+
+// traceOne runs a single trace, recovering from panics so one bad
+// transaction in a batch can't take down the whole worker pool.
+func traceOne(config gethutil.TraceConfig) (br BatchResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			br = BatchResult{Err: fmt.Sprintf("panic while tracing: %v", r)}
+		}
+	}()
+
+	result, err := gethutil.Trace(config)
+	if err != nil {
+		return BatchResult{Err: err.Error()}
+	}
+	return BatchResult{Result: result}
+}
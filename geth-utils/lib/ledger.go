@@ -0,0 +1,62 @@
+package main
+
+/*
+   #include <stdlib.h>
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+
+	"main/gethutil/ledger"
+)
+
+// OpenLedger opens (creating if necessary) a persistent Pebble-backed
+// ledger at path and returns a handle for TraceConfig.ledger_handle to
+// reference. Returns 0 and sets outErr on failure.
+//
+//export OpenLedger
+func OpenLedger(pathStr *C.char, outErr **C.char) C.ulonglong {
+	l, err := ledger.Open(C.GoString(pathStr))
+	if err != nil {
+		*outErr = C.CString(fmt.Sprintf("failed to open ledger: %v", err))
+		return 0
+	}
+	return C.ulonglong(ledger.Register(l))
+}
+
+// CloseLedger releases the ledger opened under handle. Returns nil on
+// success, or an error string the caller owns and must free.
+//
+//export CloseLedger
+func CloseLedger(handle C.ulonglong) *C.char {
+	if err := ledger.Close(uint64(handle)); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// SeedLedger bulk-writes accountsStr (a JSON array of ledger.SeedAccount)
+// into the ledger opened under handle at the given block height. This is
+// the write side OpenLedger was missing: without it a ledger could only
+// ever be opened empty, so pre-seeding a large chain state once and tracing
+// against it repeatedly wasn't actually possible. Returns nil on success,
+// or an error string the caller owns and must free.
+//
+//export SeedLedger
+func SeedLedger(handle C.ulonglong, height C.ulonglong, accountsStr *C.char) *C.char {
+	l, ok := ledger.Lookup(uint64(handle))
+	if !ok {
+		return C.CString(fmt.Sprintf("unknown ledger handle %d", uint64(handle)))
+	}
+
+	var accounts []ledger.SeedAccount
+	if err := json.Unmarshal([]byte(C.GoString(accountsStr)), &accounts); err != nil {
+		return C.CString(fmt.Sprintf("failed to unmarshal accounts: %v", err))
+	}
+
+	if err := l.Seed(uint64(height), accounts); err != nil {
+		return C.CString(fmt.Sprintf("failed to seed ledger: %v", err))
+	}
+	return nil
+}